@@ -0,0 +1,179 @@
+// Copyright 2020 Red Hat, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	"github.com/kiegroup/kogito-cloud-operator/pkg/client"
+	"github.com/kiegroup/kogito-cloud-operator/pkg/client/kubernetes"
+	consolev1 "github.com/openshift/api/console/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/discovery"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// relatedImageConsolePluginEnvVar names the plugin container image via the OLM related-image
+// convention, so disconnected/air-gapped installs resolve it without reaching an external registry.
+const relatedImageConsolePluginEnvVar = "RELATED_IMAGE_CONSOLEPLUGIN"
+
+// skipConsolePluginEnvVar, when set to a truthy value, disables console plugin integration
+// entirely: ConsolePluginReconciler won't create the plugin Deployment/Service/ConsolePlugin, and
+// KogitoRuntimeReconciler won't annotate Services for plugin discovery either. Shared by both
+// reconcilers (both call skipConsolePlugin, defined below) so operators only have one
+// flag to set rather than needing to disable each half of the feature separately.
+const skipConsolePluginEnvVar = "SKIP_CONSOLE_PLUGIN"
+
+const consolePluginName = "kogito-console-plugin"
+
+// operatorNamespaceEnvVar is set on the operator's own Deployment via the downward API
+// (fieldRef: metadata.namespace). The plugin Deployment/Service/ConsolePlugin always live in the
+// operator's install namespace, never in req.Namespace: ConsolePlugin is cluster-scoped, so
+// req.Namespace on a reconcile triggered by watching it is always empty.
+const operatorNamespaceEnvVar = "POD_NAMESPACE"
+
+// ConsolePluginReconciler manages the lifecycle of the optional OpenShift Console dynamic plugin
+// that surfaces KogitoRuntime-specific views (endpoints, metrics, DataIndex and KogitoInfra links).
+// It's a no-op when the ConsolePlugin CRD isn't installed or when explicitly disabled.
+type ConsolePluginReconciler struct {
+	*client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=console.openshift.io,resources=consoleplugins,verbs=get;create;list;watch;delete;update
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;create;list;watch;delete;update
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;create;list;watch;delete;update
+
+// Reconcile creates the console plugin Deployment, Service and ConsolePlugin resource when they
+// don't exist yet. Existing ones are left as they are, even if they differ from what it would create.
+func (r *ConsolePluginReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	r.Log = ctrl.Log.WithName("consoleplugin")
+	if skipConsolePlugin() {
+		r.Log.V(1).Info("Console plugin creation disabled via " + skipConsolePluginEnvVar)
+		return
+	}
+
+	image := os.Getenv(relatedImageConsolePluginEnvVar)
+	if image == "" {
+		r.Log.Info(relatedImageConsolePluginEnvVar + " not set, skipping console plugin reconciliation")
+		return
+	}
+	namespace := os.Getenv(operatorNamespaceEnvVar)
+	if namespace == "" {
+		err = fmt.Errorf("%s is not set, cannot determine where to place the console plugin Deployment", operatorNamespaceEnvVar)
+		return
+	}
+
+	if err = kubernetes.ResourceC(r.Client).CreateIfNotExists(r.deployment(namespace, image)); err != nil {
+		r.Log.Error(err, "Fail to create console plugin Deployment")
+		return
+	}
+	if err = kubernetes.ResourceC(r.Client).CreateIfNotExists(r.service(namespace)); err != nil {
+		r.Log.Error(err, "Fail to create console plugin Service")
+		return
+	}
+	if err = kubernetes.ResourceC(r.Client).CreateIfNotExists(r.consolePlugin(namespace)); err != nil {
+		r.Log.Error(err, "Fail to create ConsolePlugin resource")
+		return
+	}
+	return
+}
+
+func (r *ConsolePluginReconciler) deployment(namespace, image string) *appsv1.Deployment {
+	replicas := int32(1)
+	labels := map[string]string{"app": consolePluginName}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: consolePluginName, Namespace: namespace, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  consolePluginName,
+							Image: image,
+							Ports: []corev1.ContainerPort{{ContainerPort: 9443, Protocol: corev1.ProtocolTCP}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *ConsolePluginReconciler) service(namespace string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: consolePluginName, Namespace: namespace},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": consolePluginName},
+			Ports:    []corev1.ServicePort{{Port: 9443, TargetPort: intstr.FromInt(9443)}},
+		},
+	}
+}
+
+func (r *ConsolePluginReconciler) consolePlugin(namespace string) *consolev1.ConsolePlugin {
+	return &consolev1.ConsolePlugin{
+		ObjectMeta: metav1.ObjectMeta{Name: consolePluginName},
+		Spec: consolev1.ConsolePluginSpec{
+			DisplayName: "Kogito Runtimes",
+			Service: consolev1.ConsolePluginService{
+				Name:      consolePluginName,
+				Namespace: namespace,
+				Port:      9443,
+				BasePath:  "/",
+			},
+		},
+	}
+}
+
+func skipConsolePlugin() bool {
+	skip, _ := strconv.ParseBool(os.Getenv(skipConsolePluginEnvVar))
+	return skip
+}
+
+// SetupWithManager registers the controller with manager, but only when the ConsolePlugin CRD is
+// actually installed on the cluster.
+func (r *ConsolePluginReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Log = ctrl.Log.WithName("consoleplugin")
+
+	dc, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	caps, err := discoverClusterCapabilities(dc)
+	if err != nil {
+		return err
+	}
+	if !caps.consolePlugin {
+		r.Log.Info("ConsolePlugin CRD not found, skipping console plugin controller setup")
+		return nil
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&consolev1.ConsolePlugin{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Complete(r)
+}
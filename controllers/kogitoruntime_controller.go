@@ -15,30 +15,87 @@
 package controllers
 
 import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
 	"github.com/kiegroup/kogito-cloud-operator/api/v1beta1"
 	"github.com/kiegroup/kogito-cloud-operator/pkg/client"
 	"github.com/kiegroup/kogito-cloud-operator/pkg/client/kubernetes"
 	"github.com/kiegroup/kogito-cloud-operator/pkg/infrastructure"
+	"github.com/kiegroup/kogito-cloud-operator/pkg/infrastructure/protobuf"
 	"github.com/kiegroup/kogito-cloud-operator/pkg/infrastructure/services"
-	"github.com/kiegroup/kogito-cloud-operator/pkg/logger"
 	imagev1 "github.com/openshift/api/image/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// clusterCapabilitiesPollInterval controls how often the manager re-probes discovery for
+// optional APIs that weren't available at startup.
+const clusterCapabilitiesPollInterval = 2 * time.Minute
+
+// clusterCapabilities caches which optional cluster APIs were found on the API server at
+// controller startup, so reconciliation doesn't need to hit discovery on every request.
+// serviceMonitor, grafanaDashboard and appsOpenshift are recorded but nothing reads them to decide
+// whether to create a ServiceMonitor, GrafanaDashboard or DeploymentConfig: onObjectsCreate (the
+// ServiceDefinition.OnObjectsCreate hook wired up in Reconcile) doesn't consult them. They only take
+// part in the comparison watchForNewCapabilities makes to detect a change in the cluster's APIs.
+type clusterCapabilities struct {
+	route            bool
+	imageStream      bool
+	consolePlugin    bool
+	serviceMonitor   bool
+	grafanaDashboard bool
+	appsOpenshift    bool
+}
+
+// ConsolePluginAnnotation is set on the Service named after each KogitoRuntime, when the
+// ConsolePlugin CRD is installed and console plugin integration isn't disabled, so the console
+// plugin backend (see ConsolePluginReconciler) can discover which Services expose a Kogito runtime.
+const ConsolePluginAnnotation = "kogito.kie.org/console-plugin"
+
+// LoggingLevelAnnotation sets the V-level at which the reconciler emits its detail messages for a
+// single KogitoRuntime (see verboseLoggerFor). Those messages are only printed when the operator's
+// global verbosity is at least that level, so a higher value hides them rather than showing more.
+// The global level comes from the --zap-* flags (such as --zap-log-level) bound in the operator's
+// main.go via zap.Options.BindFlags; no --v flag is registered. This package only ever reads from
+// ctrl.Log, it doesn't configure it.
+const LoggingLevelAnnotation = "logging.kogito.kie.org/level"
+
 // KogitoRuntimeReconciler reconciles a KogitoRuntime object
 type KogitoRuntimeReconciler struct {
 	*client.Client
-	Log    logger.Logger
+	Log    logr.Logger
 	Scheme *runtime.Scheme
+	caps   clusterCapabilities
+}
+
+// verboseLoggerFor reads LoggingLevelAnnotation off instance and returns a logr.InfoLogger at
+// that verbosity, falling back to V(0) when the annotation is absent or not a valid integer.
+func verboseLoggerFor(log logr.Logger, instance v1beta1.KogitoService) logr.InfoLogger {
+	level := 0
+	if raw, ok := instance.GetAnnotations()[LoggingLevelAnnotation]; ok {
+		if parsed, err := strconv.Atoi(raw); err == nil {
+			level = parsed
+		}
+	}
+	return log.V(level)
 }
 
 // +kubebuilder:rbac:groups=app.kiegroup.org,resources=kogitoruntimes,verbs=get;list;watch;create;update;patch;delete
@@ -52,11 +109,13 @@ type KogitoRuntimeReconciler struct {
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=*,verbs=get;create;list;watch;create;delete;update
 // +kubebuilder:rbac:groups=route.openshift.io,resources=*,verbs=get;create;list;watch;create;delete;update
 // +kubebuilder:rbac:groups=apps.openshift.io,resources=*,verbs=get;create;list;watch;create;delete;update
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;create;list;watch;delete;update
 // +kubebuilder:rbac:groups=core,resources=*,verbs=create;delete;get;list;patch;update;watch
 
 // Reconcile reads that state of the cluster for a KogitoRuntime object and makes changes based on the state read
 // and what is in the KogitoRuntime.Spec
 func (r *KogitoRuntimeReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	r.Log = ctrl.Log.WithName("kogitoruntime").WithValues("namespace", req.Namespace, "name", req.Name)
 	r.Log.Info("Reconciling for", "KogitoRuntime", req.Name, "Namespace", req.Namespace)
 
 	instance, err := infrastructure.FetchKogitoRuntimeService(r.Client, req.Name, req.Namespace)
@@ -64,15 +123,21 @@ func (r *KogitoRuntimeReconciler) Reconcile(req ctrl.Request) (result ctrl.Resul
 		return
 	}
 	if instance == nil {
-		r.Log.Debug("Instance not found", "KogitoRuntime", req.Name, "Namespace", req.Namespace)
+		r.Log.V(1).Info("Instance not found", "KogitoRuntime", req.Name, "Namespace", req.Namespace)
 		return
 	}
+	verbose := verboseLoggerFor(r.Log, instance)
 
-	if err = r.setupRBAC(req.Namespace); err != nil {
+	if err = r.setupRBAC(req.Namespace, verbose); err != nil {
 		return
 	}
 
-	if err = infrastructure.MountProtoBufConfigMapOnDataIndex(r.Client, instance); err != nil {
+	if instance.GetSpec().GetProtoBufStorage() != nil && instance.GetSpec().GetProtoBufStorage().Type == v1beta1.ProtoBufStorageObjectStore {
+		if err = r.mountProtoBufObjectStoreOnDataIndex(instance, verbose); err != nil {
+			r.Log.Error(err, "Fail to distribute Proto Buf descriptors of Kogito runtime to object store", "Instance", instance.Name)
+			return
+		}
+	} else if err = infrastructure.MountProtoBufConfigMapOnDataIndex(r.Client, instance); err != nil {
 		r.Log.Error(err, "Fail to mount Proto Buf config map of Kogito runtime on DataIndex", "Instance", instance.Name)
 		return
 	}
@@ -95,17 +160,74 @@ func (r *KogitoRuntimeReconciler) Reconcile(req ctrl.Request) (result ctrl.Resul
 	if err != nil {
 		return
 	}
+
+	if !r.caps.route {
+		if err = r.reconcileNetworkExposure(instance, verbose); err != nil {
+			r.Log.Error(err, "Fail to expose KogitoRuntime via Ingress", "Instance", instance.Name)
+			return
+		}
+	}
+
+	if r.caps.consolePlugin && !skipConsolePlugin() {
+		if err = r.annotateServiceForConsolePlugin(instance); err != nil {
+			r.Log.Error(err, "Fail to annotate Service for console plugin discovery", "Instance", instance.Name)
+			return
+		}
+	}
+
 	if requeueAfter > 0 {
-		r.Log.Info("Waiting for all resources to be created, scheduling for 30 seconds from now")
+		verbose.Info("Waiting for all resources to be created, scheduling for 30 seconds from now")
 		result.RequeueAfter = requeueAfter
 		result.Requeue = true
 	}
 	return
 }
 
+// optionalAPIs are the cluster APIs the controller tolerates being absent, mapped to the
+// clusterCapabilities field they populate. apps.openshift.io, monitoring.coreos.com and
+// integreatly.org are probed too, but their results are only compared by watchForNewCapabilities:
+// creation of a DeploymentConfig, ServiceMonitor or GrafanaDashboard isn't skipped based on them.
+var optionalAPIs = map[schema.GroupVersion]func(*clusterCapabilities, bool){
+	{Group: "route.openshift.io", Version: "v1"}:    func(c *clusterCapabilities, ok bool) { c.route = ok },
+	{Group: "image.openshift.io", Version: "v1"}:    func(c *clusterCapabilities, ok bool) { c.imageStream = ok },
+	{Group: "console.openshift.io", Version: "v1"}:  func(c *clusterCapabilities, ok bool) { c.consolePlugin = ok },
+	{Group: "apps.openshift.io", Version: "v1"}:     func(c *clusterCapabilities, ok bool) { c.appsOpenshift = ok },
+	{Group: "monitoring.coreos.com", Version: "v1"}: func(c *clusterCapabilities, ok bool) { c.serviceMonitor = ok },
+	{Group: "integreatly.org", Version: "v1alpha1"}: func(c *clusterCapabilities, ok bool) { c.grafanaDashboard = ok },
+}
+
+// discoverClusterCapabilities probes the API server for the optional APIs the reconciler
+// adapts its behavior to, tolerating any of them being unavailable (e.g. on vanilla Kubernetes).
+func discoverClusterCapabilities(dc discovery.DiscoveryInterface) (clusterCapabilities, error) {
+	caps := clusterCapabilities{}
+	for gv, set := range optionalAPIs {
+		_, err := dc.ServerResourcesForGroupVersion(gv.String())
+		if err != nil {
+			if discovery.IsGroupDiscoveryFailedError(err) || errors.IsNotFound(err) {
+				set(&caps, false)
+				continue
+			}
+			return clusterCapabilities{}, err
+		}
+		set(&caps, true)
+	}
+	return caps, nil
+}
+
 // SetupWithManager registers the controller with manager
 func (r *KogitoRuntimeReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	r.Log.Debug("Adding watched objects for KogitoRuntime controller")
+	r.Log = ctrl.Log.WithName("kogitoruntime")
+	r.Log.V(1).Info("Adding watched objects for KogitoRuntime controller")
+
+	dc, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	caps, err := discoverClusterCapabilities(dc)
+	if err != nil {
+		return err
+	}
+	r.caps = caps
 
 	pred := predicate.Funcs{
 		// Don't watch delete events as the resource removals will be handled by its finalizer
@@ -123,14 +245,109 @@ func (r *KogitoRuntimeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	infraHandler := &handler.EnqueueRequestForOwner{IsController: false, OwnerType: &v1beta1.KogitoRuntime{}}
 	b.Watches(&source.Kind{Type: &v1beta1.KogitoInfra{}}, infraHandler)
 
-	if r.IsOpenshift() {
-		b.Owns(&routev1.Route{}).Owns(&imagev1.ImageStream{})
+	// Prefer Route on OpenShift, falling back to a plain Ingress when the Route CRD isn't
+	// installed (e.g. kind, EKS, GKE). Reconcile calls reconcileNetworkExposure to create the
+	// Ingress only in the latter case; it never creates a Route.
+	if r.caps.route {
+		b.Owns(&routev1.Route{})
+	} else {
+		b.Owns(&networkingv1.Ingress{})
+	}
+	if r.caps.imageStream {
+		b.Owns(&imagev1.ImageStream{})
+	}
+
+	// A CRD that shows up after startup (ServiceMonitor, GrafanaDashboard, Route, ...) won't be
+	// picked up by the watches registered above. Once discovery reports a change, the manager stops
+	// with an error and main exits with status 1, so the container is restarted and
+	// SetupWithManager re-probes discovery on the next start.
+	if err = mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		return watchForNewCapabilities(stop, dc, caps)
+	})); err != nil {
+		return err
 	}
 
 	return b.Complete(r)
 }
 
-func (r *KogitoRuntimeReconciler) setupRBAC(namespace string) (err error) {
+// watchForNewCapabilities periodically re-probes discovery and returns an error, which stops the
+// manager, as soon as the set of available optional APIs differs from baseline (an API that
+// appears or one that goes away). A probe that fails is ignored until the next tick.
+func watchForNewCapabilities(stop <-chan struct{}, dc discovery.DiscoveryInterface, baseline clusterCapabilities) error {
+	ticker := time.NewTicker(clusterCapabilitiesPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			current, err := discoverClusterCapabilities(dc)
+			if err != nil {
+				continue
+			}
+			if current != baseline {
+				return fmt.Errorf("cluster capabilities changed (%+v -> %+v), restarting to pick up new watches", baseline, current)
+			}
+		}
+	}
+}
+
+// reconcileNetworkExposure creates a networking.k8s.io/v1 Ingress for instance (only called when
+// the Route CRD isn't available) and records the resulting URL on its status, mirroring the URL
+// a Route would otherwise expose. It's a no-op, not an error, when spec.host isn't set: that field
+// is optional, and erroring here would fail the reconcile on every retry until the user edits the
+// CR.
+func (r *KogitoRuntimeReconciler) reconcileNetworkExposure(instance v1beta1.KogitoService, verbose logr.InfoLogger) error {
+	host := instance.GetSpec().GetHost()
+	if host == "" {
+		r.Log.Info("spec.host not set, skipping Ingress creation: no Route CRD is available on this cluster to derive one", "Instance", instance.GetName())
+		return nil
+	}
+
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: instance.GetName(), Namespace: instance.GetNamespace()},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Path:     "/",
+									PathType: &pathType,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: instance.GetName(),
+											Port: networkingv1.ServiceBackendPort{Number: 8080},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	scheme := "http"
+	if tlsSecret := instance.GetSpec().GetTLSSecret(); tlsSecret != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{host}, SecretName: tlsSecret}}
+		scheme = "https"
+	}
+
+	if err := kubernetes.ResourceC(r.Client).CreateIfNotExists(ingress); err != nil {
+		return err
+	}
+
+	verbose.Info("Exposed KogitoRuntime via Ingress", "Host", host)
+	instance.GetStatus().SetExternalURI(fmt.Sprintf("%s://%s", scheme, host))
+	return kubernetes.ResourceC(r.Client).UpdateStatus(instance)
+}
+
+func (r *KogitoRuntimeReconciler) setupRBAC(namespace string, verbose logr.InfoLogger) (err error) {
 	// create service viewer role
 	if err = kubernetes.ResourceC(r.Client).CreateIfNotExists(getServiceViewerRole(namespace)); err != nil {
 		r.Log.Error(err, "Fail to create role for service viewer")
@@ -148,5 +365,102 @@ func (r *KogitoRuntimeReconciler) setupRBAC(namespace string) (err error) {
 		r.Log.Error(err, "Fail to create role binding for service viewer")
 		return
 	}
+	verbose.Info("Ensured service viewer RBAC resources exist", "Namespace", namespace)
 	return
 }
+
+// annotateServiceForConsolePlugin marks instance's Service with ConsolePluginAnnotation so the
+// OpenShift console plugin backend managed by ConsolePluginReconciler can discover it.
+func (r *KogitoRuntimeReconciler) annotateServiceForConsolePlugin(instance v1beta1.KogitoService) error {
+	svc := &corev1.Service{}
+	exists, err := kubernetes.ResourceC(r.Client).FetchWithKey(types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()}, svc)
+	if err != nil || !exists {
+		return err
+	}
+	if svc.Annotations[ConsolePluginAnnotation] == "enabled" {
+		return nil
+	}
+	if svc.Annotations == nil {
+		svc.Annotations = map[string]string{}
+	}
+	svc.Annotations[ConsolePluginAnnotation] = "enabled"
+	return kubernetes.ResourceC(r.Client).Update(svc)
+}
+
+// mountProtoBufObjectStoreOnDataIndex uploads instance's ProtoBuf descriptors to the S3-compatible
+// object store configured on spec.protoBufStorage.objectStore, records their versions on the
+// KogitoRuntime status, garbage collects descriptors no longer referenced, and injects the
+// KOGITO_PROTOBUF_S3_* env vars into the DataIndex deployment so it can stream them at startup
+// instead of mounting a ConfigMap.
+func (r *KogitoRuntimeReconciler) mountProtoBufObjectStoreOnDataIndex(instance v1beta1.KogitoService, verbose logr.InfoLogger) error {
+	objectStore := instance.GetSpec().GetProtoBufStorage().ObjectStore
+
+	secret := &corev1.Secret{}
+	exists, err := kubernetes.ResourceC(r.Client).FetchWithKey(types.NamespacedName{Name: objectStore.CredentialsSecret, Namespace: instance.GetNamespace()}, secret)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("credentials secret %s not found in namespace %s", objectStore.CredentialsSecret, instance.GetNamespace())
+	}
+
+	cfg := protobuf.ClientConfig{
+		Endpoint:        objectStore.Endpoint,
+		Region:          objectStore.Region,
+		Bucket:          objectStore.Bucket,
+		PathStyle:       objectStore.PathStyle,
+		AccessKeyID:     string(secret.Data["accessKeyId"]),
+		SecretAccessKey: string(secret.Data["secretAccessKey"]),
+	}
+	s3Client, err := protobuf.NewClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	descriptors, err := infrastructure.GetProtoBufDescriptors(r.Client, instance)
+	if err != nil {
+		return err
+	}
+	versions, err := protobuf.Upload(s3Client, instance.GetNamespace(), instance.GetName(), descriptors)
+	if err != nil {
+		return err
+	}
+	verbose.Info("Uploaded Proto Buf descriptors to object store", "Count", len(versions))
+
+	instance.GetStatus().SetProtoBufObjectVersions(versions)
+	if err = kubernetes.ResourceC(r.Client).UpdateStatus(instance); err != nil {
+		return err
+	}
+
+	liveShas, err := r.liveProtoBufShasInNamespace(instance.GetNamespace())
+	if err != nil {
+		r.Log.Error(err, "Fail to collect live Proto Buf versions for garbage collection", "Instance", instance.GetName())
+	} else if err = protobuf.GarbageCollect(s3Client, instance.GetNamespace(), liveShas); err != nil {
+		r.Log.Error(err, "Fail to garbage collect stale Proto Buf descriptors", "Instance", instance.GetName())
+	}
+
+	return infrastructure.InjectEnvVarsIntoDataIndex(r.Client, instance, protobuf.EnvVars(cfg, objectStore.CredentialsSecret))
+}
+
+// liveProtoBufShasInNamespace returns the union of every sha256 recorded on the status of every
+// KogitoRuntime in namespace using object-store ProtoBuf distribution. GarbageCollect scans the
+// whole namespace prefix in the object store, so it must be compared against every runtime's
+// versions, not just the one instance that triggered this reconcile: otherwise it would delete the
+// descriptors of every other KogitoRuntime sharing the bucket.
+func (r *KogitoRuntimeReconciler) liveProtoBufShasInNamespace(namespace string) (map[string]bool, error) {
+	runtimeList := &v1beta1.KogitoRuntimeList{}
+	if err := kubernetes.ResourceC(r.Client).ListWithNamespace(namespace, runtimeList); err != nil {
+		return nil, err
+	}
+	liveShas := map[string]bool{}
+	for i := range runtimeList.Items {
+		runtime := &runtimeList.Items[i]
+		if runtime.GetSpec().GetProtoBufStorage() == nil || runtime.GetSpec().GetProtoBufStorage().Type != v1beta1.ProtoBufStorageObjectStore {
+			continue
+		}
+		for _, sha := range runtime.GetStatus().GetProtoBufObjectVersions() {
+			liveShas[sha] = true
+		}
+	}
+	return liveShas, nil
+}
@@ -0,0 +1,269 @@
+// Copyright 2020 Red Hat, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"github.com/kiegroup/kogito-cloud-operator/api/v1beta1"
+	"github.com/kiegroup/kogito-cloud-operator/pkg/client"
+	"github.com/kiegroup/kogito-cloud-operator/pkg/client/kubernetes"
+	"github.com/kiegroup/kogito-cloud-operator/pkg/logger"
+	imagev1 "github.com/openshift/api/image/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/record"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// AutoCreatedAnnotation marks a KogitoRuntime that was materialized by the
+// KogitoRuntimeTemplateReconciler rather than hand-authored by a user.
+const AutoCreatedAnnotation = "kogito.kie.org/auto-created"
+
+// PendingApprovalAnnotation marks an auto-created KogitoRuntime as awaiting user approval. The
+// instance is created with spec.replicas 0 and nothing in the operator reads this annotation, so
+// removing it doesn't scale the instance up; users set spec.replicas themselves to adopt it.
+const PendingApprovalAnnotation = "kogito.kie.org/pending-approval"
+
+// defaultBindingAnnotationPrefix, suffixed with a binding name, lets a user pin which candidate
+// resource to use for a given KogitoRuntimeTemplate binding when more than one match exists.
+const defaultBindingAnnotationPrefix = "kogito.kie.org/default-binding."
+
+// KogitoRuntimeTemplateReconciler watches KogitoBuild completions and KogitoRuntimeTemplate CRs
+// and, once every binding a template requires can be resolved unambiguously from resources
+// already in the namespace, materializes a draft KogitoRuntime for the user to approve.
+type KogitoRuntimeTemplateReconciler struct {
+	*client.Client
+	Log      logger.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=app.kiegroup.org,resources=kogitoruntimetemplates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=app.kiegroup.org,resources=kogitoruntimetemplates/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=app.kiegroup.org,resources=kogitoruntimes,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=app.kiegroup.org,resources=kogitobuilds,verbs=get;list;watch
+// +kubebuilder:rbac:groups=app.kiegroup.org,resources=kogitoinfras,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// Reconcile resolves a KogitoRuntimeTemplate's bindings against resources in its namespace and,
+// once all of them are satisfied, creates a pending-approval KogitoRuntime.
+func (r *KogitoRuntimeTemplateReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	r.Log.Info("Reconciling for", "KogitoRuntimeTemplate", req.Name, "Namespace", req.Namespace)
+
+	template := &v1beta1.KogitoRuntimeTemplate{}
+	if err = r.Client.ControlCli.Get(req.NamespacedName, template); err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return
+	}
+
+	if template.Status.CreatedRuntimeName != "" {
+		r.Log.Debug("KogitoRuntime already materialized for this template, skipping", "KogitoRuntimeTemplate", req.Name, "KogitoRuntime", template.Status.CreatedRuntimeName)
+		return
+	}
+
+	resolved, unresolved, ambiguous := r.resolveBindings(template)
+	if len(ambiguous) > 0 {
+		r.Recorder.Eventf(template, corev1.EventTypeWarning, "AmbiguousBinding",
+			"binding(s) %v matched more than one candidate; annotate the template with %s<name> to pick one", ambiguous, defaultBindingAnnotationPrefix)
+		return
+	}
+	if len(unresolved) > 0 {
+		r.Log.Debug("Not every binding is satisfied yet, waiting for more resources", "KogitoRuntimeTemplate", req.Name, "Unresolved", unresolved)
+		return
+	}
+
+	runtimeInstance := r.newKogitoRuntime(template, resolved)
+	if err = kubernetes.ResourceC(r.Client).Create(runtimeInstance); err != nil {
+		r.Log.Error(err, "Fail to create auto-instantiated KogitoRuntime", "KogitoRuntimeTemplate", req.Name)
+		return
+	}
+	r.Recorder.Eventf(template, corev1.EventTypeNormal, "RuntimeCreated", "created KogitoRuntime %s pending approval", runtimeInstance.Name)
+
+	template.Status.CreatedRuntimeName = runtimeInstance.Name
+	err = r.Client.ControlCli.Status().Update(template)
+	return
+}
+
+// resolveBindings matches each binding required by the template against candidate resources in
+// the template's namespace, honoring a user-set default-binding annotation on ties. The value
+// resolved for an image stream tag binding is the concrete pullable image reference, not the
+// ImageStream's bare name.
+func (r *KogitoRuntimeTemplateReconciler) resolveBindings(template *v1beta1.KogitoRuntimeTemplate) (resolved map[string]string, unresolved []string, ambiguous []string) {
+	resolved = map[string]string{}
+	for _, binding := range template.Spec.Bindings {
+		candidates, err := r.findBindingCandidates(template.Namespace, binding)
+		if err != nil {
+			r.Log.Error(err, "Fail to list candidates for binding", "Binding", binding.Name)
+			unresolved = append(unresolved, binding.Name)
+			continue
+		}
+		name, ok := resolveBinding(candidates.names, template.Annotations[defaultBindingAnnotationPrefix+binding.Name])
+		switch {
+		case ok && candidates.images != nil:
+			resolved[binding.Name] = candidates.images[name]
+		case ok:
+			resolved[binding.Name] = name
+		case len(candidates.names) == 0:
+			unresolved = append(unresolved, binding.Name)
+		default:
+			ambiguous = append(ambiguous, binding.Name)
+		}
+	}
+	return
+}
+
+// resolveBinding picks the single candidate a binding should use: the sole candidate if there's
+// only one, or the one matching defaultBinding (the user's default-binding annotation value) on a
+// tie. It returns ok=false when the binding is still unresolved (no candidates) or ambiguous
+// (more than one candidate and no matching default).
+func resolveBinding(candidates []string, defaultBinding string) (name string, ok bool) {
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+	if defaultBinding != "" && contains(candidates, defaultBinding) {
+		return defaultBinding, true
+	}
+	return "", false
+}
+
+// bindingCandidates is the set of resources in a namespace that could satisfy a template binding.
+// images is only populated for image stream tag bindings, mapping a candidate's name to the
+// concrete pullable image reference it resolves to; it's nil for KogitoInfra bindings, where the
+// resource's own name is the value a KogitoRuntime needs.
+type bindingCandidates struct {
+	names  []string
+	images map[string]string
+}
+
+// findBindingCandidates lists the resources in namespace that could satisfy binding, based on
+// its kind (an image stream tag or a KogitoInfra of a given type).
+func (r *KogitoRuntimeTemplateReconciler) findBindingCandidates(namespace string, binding v1beta1.TemplateBinding) (bindingCandidates, error) {
+	switch binding.Kind {
+	case v1beta1.TemplateBindingImageStreamTag:
+		return r.findImageStreamTagCandidates(namespace, binding.ImageStreamTag)
+	default:
+		names, err := r.findKogitoInfraCandidates(namespace, binding.Kind)
+		return bindingCandidates{names: names}, err
+	}
+}
+
+// findImageStreamTagCandidates lists every ImageStream in namespace carrying a tag named tag,
+// resolving each to the concrete image reference (registry/name@digest or registry/name:tag) its
+// tag currently points at, so callers never have to re-derive a pullable image from a bare
+// ImageStream name.
+func (r *KogitoRuntimeTemplateReconciler) findImageStreamTagCandidates(namespace, tag string) (bindingCandidates, error) {
+	isList := &imagev1.ImageStreamList{}
+	if err := kubernetes.ResourceC(r.Client).ListWithNamespace(namespace, isList); err != nil {
+		return bindingCandidates{}, err
+	}
+	candidates := bindingCandidates{images: map[string]string{}}
+	for _, is := range isList.Items {
+		for _, t := range is.Status.Tags {
+			if t.Tag != tag || len(t.Items) == 0 {
+				continue
+			}
+			candidates.names = append(candidates.names, is.Name)
+			candidates.images[is.Name] = t.Items[0].DockerImageReference
+		}
+	}
+	return candidates, nil
+}
+
+func (r *KogitoRuntimeTemplateReconciler) findKogitoInfraCandidates(namespace string, infraType v1beta1.KogitoInfraResourceType) ([]string, error) {
+	infraList := &v1beta1.KogitoInfraList{}
+	if err := kubernetes.ResourceC(r.Client).ListWithNamespace(namespace, infraList); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, infra := range infraList.Items {
+		if infra.Spec.InfraType == infraType {
+			names = append(names, infra.Name)
+		}
+	}
+	return names, nil
+}
+
+// newKogitoRuntime materializes a draft, scaled-to-zero KogitoRuntime from template using the
+// resolved binding values, annotated as auto-created and pending the user's approval.
+func (r *KogitoRuntimeTemplateReconciler) newKogitoRuntime(template *v1beta1.KogitoRuntimeTemplate, resolved map[string]string) *v1beta1.KogitoRuntime {
+	replicas := int32(0)
+	runtimeInstance := &v1beta1.KogitoRuntime{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      template.Name,
+			Namespace: template.Namespace,
+			Annotations: map[string]string{
+				AutoCreatedAnnotation:     "true",
+				PendingApprovalAnnotation: "true",
+			},
+		},
+		Spec: v1beta1.KogitoRuntimeSpec{
+			Replicas: &replicas,
+		},
+	}
+	if image, ok := resolved[template.Spec.ImageStreamBindingName]; ok {
+		runtimeInstance.Spec.Image = image
+	}
+	for _, binding := range template.Spec.Bindings {
+		if name, ok := resolved[binding.Name]; ok && binding.Kind != v1beta1.TemplateBindingImageStreamTag {
+			runtimeInstance.Spec.Infra = append(runtimeInstance.Spec.Infra, name)
+		}
+	}
+	return runtimeInstance
+}
+
+// SetupWithManager registers the controller with manager
+func (r *KogitoRuntimeTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Log.Debug("Adding watched objects for KogitoRuntimeTemplate controller")
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1beta1.KogitoRuntimeTemplate{}).
+		Watches(&source.Kind{Type: &v1beta1.KogitoBuild{}}, handler.EnqueueRequestsFromMapFunc(r.mapKogitoBuildToTemplates)).
+		Complete(r)
+}
+
+// mapKogitoBuildToTemplates re-queues every KogitoRuntimeTemplate in a KogitoBuild's namespace
+// whenever the build changes, since a newly completed build may satisfy a pending binding.
+func (r *KogitoRuntimeTemplateReconciler) mapKogitoBuildToTemplates(obj handler.MapObject) []reconcile.Request {
+	build, ok := obj.Object.(*v1beta1.KogitoBuild)
+	if !ok {
+		return nil
+	}
+	templateList := &v1beta1.KogitoRuntimeTemplateList{}
+	if err := kubernetes.ResourceC(r.Client).ListWithNamespace(build.Namespace, templateList); err != nil {
+		r.Log.Error(err, "Fail to list KogitoRuntimeTemplates for KogitoBuild watch", "KogitoBuild", build.Name)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(templateList.Items))
+	for _, template := range templateList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: template.Name, Namespace: template.Namespace}})
+	}
+	return requests
+}
+
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
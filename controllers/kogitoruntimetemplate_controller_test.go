@@ -0,0 +1,58 @@
+// Copyright 2020 Red Hat, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveBindingNoCandidates(t *testing.T) {
+	name, ok := resolveBinding(nil, "")
+	assert.False(t, ok)
+	assert.Empty(t, name)
+}
+
+func TestResolveBindingSingleCandidate(t *testing.T) {
+	name, ok := resolveBinding([]string{"my-infra"}, "")
+	assert.True(t, ok)
+	assert.Equal(t, "my-infra", name)
+}
+
+func TestResolveBindingAmbiguousWithoutDefault(t *testing.T) {
+	name, ok := resolveBinding([]string{"infra-a", "infra-b"}, "")
+	assert.False(t, ok)
+	assert.Empty(t, name)
+}
+
+func TestResolveBindingAmbiguousResolvedByDefault(t *testing.T) {
+	name, ok := resolveBinding([]string{"infra-a", "infra-b"}, "infra-b")
+	assert.True(t, ok)
+	assert.Equal(t, "infra-b", name)
+}
+
+func TestResolveBindingDefaultNotAmongCandidatesStaysAmbiguous(t *testing.T) {
+	name, ok := resolveBinding([]string{"infra-a", "infra-b"}, "infra-c")
+	assert.False(t, ok)
+	assert.Empty(t, name)
+}
+
+func TestContains(t *testing.T) {
+	values := []string{"a", "b", "c"}
+	assert.True(t, contains(values, "b"))
+	assert.False(t, contains(values, "d"))
+	assert.False(t, contains(nil, "a"))
+}
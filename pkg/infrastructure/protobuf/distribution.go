@@ -0,0 +1,125 @@
+// Copyright 2020 Red Hat, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Env var names injected into the DataIndex deployment so it can stream descriptors from the
+// object store at startup instead of mounting a ConfigMap.
+const (
+	EnvVarS3Endpoint  = "KOGITO_PROTOBUF_S3_ENDPOINT"
+	EnvVarS3Region    = "KOGITO_PROTOBUF_S3_REGION"
+	EnvVarS3Bucket    = "KOGITO_PROTOBUF_S3_BUCKET"
+	EnvVarS3PathStyle = "KOGITO_PROTOBUF_S3_PATH_STYLE"
+	EnvVarS3AccessKey = "KOGITO_PROTOBUF_S3_ACCESS_KEY_ID"
+	EnvVarS3SecretKey = "KOGITO_PROTOBUF_S3_SECRET_ACCESS_KEY"
+)
+
+// ObjectKey builds the deterministic key a descriptor is stored under: <namespace>/<runtime>/<sha256>.proto.
+func ObjectKey(namespace, runtimeName, sha string) string {
+	return fmt.Sprintf("%s/%s/%s.proto", namespace, runtimeName, sha)
+}
+
+// Sha256Hex returns the lowercase hex-encoded sha256 digest of content, used both as part of the
+// object key and as the version recorded on the KogitoRuntime status.
+func Sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Upload pushes each descriptor (keyed by filename) to the object store under its deterministic
+// key and returns a filename -> sha256 map suitable for recording on the KogitoRuntime status.
+func Upload(cli *Client, namespace, runtimeName string, descriptors map[string][]byte) (map[string]string, error) {
+	versions := make(map[string]string, len(descriptors))
+	for filename, content := range descriptors {
+		sha := Sha256Hex(content)
+		if err := cli.Put(ObjectKey(namespace, runtimeName, sha), content); err != nil {
+			return nil, fmt.Errorf("failed to upload proto buf descriptor %s: %v", filename, err)
+		}
+		versions[filename] = sha
+	}
+	return versions, nil
+}
+
+// GarbageCollect deletes every object under the namespace prefix whose sha doesn't appear in
+// liveShas. liveShas must be the union of every KogitoRuntime currently in the namespace, not just
+// the one that triggered this pass: scoping the scan to a single runtime's own prefix would never
+// clean up the descriptors of a KogitoRuntime that was since deleted.
+func GarbageCollect(cli *Client, namespace string, liveShas map[string]bool) error {
+	prefix := namespace + "/"
+	keys, err := cli.ListKeys(prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list proto buf descriptors under %s: %v", prefix, err)
+	}
+	for _, key := range staleKeys(keys, liveShas) {
+		if err := cli.Delete(key); err != nil {
+			return fmt.Errorf("failed to delete stale proto buf descriptor %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// staleKeys returns the keys among candidates whose sha256 component doesn't appear in liveShas.
+func staleKeys(candidates []string, liveShas map[string]bool) []string {
+	var stale []string
+	for _, key := range candidates {
+		if !liveShas[shaFromKey(key)] {
+			stale = append(stale, key)
+		}
+	}
+	return stale
+}
+
+// shaFromKey extracts the sha256 component from a key built by ObjectKey.
+func shaFromKey(key string) string {
+	base := key[strings.LastIndex(key, "/")+1:]
+	return strings.TrimSuffix(base, ".proto")
+}
+
+// EnvVars builds the KOGITO_PROTOBUF_S3_* environment variables the DataIndex deployment needs to
+// stream descriptors from the object store, pulling credentials from the user-provided secret.
+func EnvVars(cfg ClientConfig, credentialsSecretName string) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: EnvVarS3Endpoint, Value: cfg.Endpoint},
+		{Name: EnvVarS3Region, Value: cfg.Region},
+		{Name: EnvVarS3Bucket, Value: cfg.Bucket},
+		{Name: EnvVarS3PathStyle, Value: fmt.Sprintf("%t", cfg.PathStyle)},
+		{
+			Name: EnvVarS3AccessKey,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: credentialsSecretName},
+					Key:                  "accessKeyId",
+				},
+			},
+		},
+		{
+			Name: EnvVarS3SecretKey,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: credentialsSecretName},
+					Key:                  "secretAccessKey",
+				},
+			},
+		},
+	}
+}
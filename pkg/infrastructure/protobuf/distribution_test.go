@@ -0,0 +1,59 @@
+// Copyright 2020 Red Hat, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectKey(t *testing.T) {
+	key := ObjectKey("my-namespace", "my-runtime", "deadbeef")
+	assert.Equal(t, "my-namespace/my-runtime/deadbeef.proto", key)
+}
+
+func TestSha256Hex(t *testing.T) {
+	sha := Sha256Hex([]byte("hello"))
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", sha)
+}
+
+func TestShaFromKey(t *testing.T) {
+	assert.Equal(t, "deadbeef", shaFromKey("my-namespace/my-runtime/deadbeef.proto"))
+}
+
+func TestStaleKeysDropsLiveShas(t *testing.T) {
+	keys := []string{
+		"ns/runtime-a/sha1.proto",
+		"ns/runtime-b/sha2.proto",
+		"ns/runtime-a/sha3.proto",
+	}
+	liveShas := map[string]bool{"sha1": true, "sha3": true}
+
+	assert.Equal(t, []string{"ns/runtime-b/sha2.proto"}, staleKeys(keys, liveShas))
+}
+
+func TestStaleKeysEmptyWhenAllLive(t *testing.T) {
+	keys := []string{"ns/runtime-a/sha1.proto"}
+	liveShas := map[string]bool{"sha1": true}
+
+	assert.Empty(t, staleKeys(keys, liveShas))
+}
+
+func TestStaleKeysAllStaleWhenNoneLive(t *testing.T) {
+	keys := []string{"ns/runtime-a/sha1.proto", "ns/runtime-b/sha2.proto"}
+
+	assert.Equal(t, keys, staleKeys(keys, map[string]bool{}))
+}
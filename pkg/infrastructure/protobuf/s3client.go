@@ -0,0 +1,105 @@
+// Copyright 2020 Red Hat, Inc. and/or its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package protobuf uploads and garbage collects KogitoRuntime ProtoBuf descriptors in an
+// S3-compatible object store, as an alternative to the default ConfigMap distribution which is
+// limited by etcd's 1MiB object size.
+package protobuf
+
+import (
+	"bytes"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// maxRetries bounds the exponential backoff the AWS SDK applies to transient S3 errors
+// (connection resets, 5xx responses) before giving up.
+const maxRetries = 5
+
+// ClientConfig carries the connection details for an S3-compatible endpoint, as configured on a
+// KogitoRuntime's spec.protoBufStorage.objectStore.
+type ClientConfig struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PathStyle       bool
+}
+
+// Client is a thin wrapper around an S3 API client scoped to a single bucket, with retry/backoff
+// already configured.
+type Client struct {
+	api    *s3.S3
+	bucket string
+}
+
+// NewClient builds a Client for cfg, configuring exponential backoff retries so transient
+// failures talking to the object store don't fail a reconcile outright.
+func NewClient(cfg ClientConfig) (*Client, error) {
+	awsConfig := aws.NewConfig().
+		WithRegion(cfg.Region).
+		WithS3ForcePathStyle(cfg.PathStyle).
+		WithMaxRetries(maxRetries).
+		WithCredentials(credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, ""))
+	if cfg.Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(cfg.Endpoint)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config: *awsConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{api: s3.New(sess), bucket: cfg.Bucket}, nil
+}
+
+// Put uploads content under key, overwriting any existing object.
+func (c *Client) Put(key string, content []byte) error {
+	_, err := c.api.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(content),
+	})
+	return err
+}
+
+// Delete removes the object at key. It's not an error for key to already be gone.
+func (c *Client) Delete(key string) error {
+	_, err := c.api.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// ListKeys returns every object key under prefix.
+func (c *Client) ListKeys(prefix string) ([]string, error) {
+	var keys []string
+	err := c.api.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			keys = append(keys, aws.StringValue(obj.Key))
+		}
+		return true
+	})
+	return keys, err
+}